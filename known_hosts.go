@@ -0,0 +1,114 @@
+package sshtunnel
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Options customizes tunnel construction beyond the basic parameters accepted by
+// NewSSHTunnel, NewReverseSSHTunnel, and NewSSHTunnelWithJumps. The zero value keeps
+// the existing defaults.
+type Options struct {
+	// HostKeyCallback verifies the SSH server's host key for every hop. If nil, the
+	// tunnel falls back to accepting any host key, which is insecure but preserves
+	// prior behavior. Use KnownHostsCallback or KnownHostsCallbackTOFU to verify
+	// against a known_hosts file instead.
+	HostKeyCallback ssh.HostKeyCallback
+}
+
+func (opts Options) apply(tunnel *SSHTunnel) {
+	if opts.HostKeyCallback == nil {
+		return
+	}
+	for _, config := range tunnel.Configs {
+		config.HostKeyCallback = opts.HostKeyCallback
+	}
+}
+
+// NewSSHTunnelWithOptions is like NewSSHTunnel but applies opts to the resulting
+// tunnel, e.g. to install a verifying HostKeyCallback in place of the default
+// insecure "accept any key" behavior.
+func NewSSHTunnelWithOptions(tunnel string, auth ssh.AuthMethod, destination string, localport string, opts Options) *SSHTunnel {
+	sshTunnel := NewSSHTunnel(tunnel, auth, destination, localport)
+	opts.apply(sshTunnel)
+	return sshTunnel
+}
+
+// NewReverseSSHTunnelWithOptions is like NewReverseSSHTunnel but applies opts to
+// the resulting tunnel, e.g. to install a verifying HostKeyCallback in place of the
+// default insecure "accept any key" behavior.
+func NewReverseSSHTunnelWithOptions(tunnel string, auth ssh.AuthMethod, remote string, localDestination string, opts Options) *SSHTunnel {
+	sshTunnel := NewReverseSSHTunnel(tunnel, auth, remote, localDestination)
+	opts.apply(sshTunnel)
+	return sshTunnel
+}
+
+// NewSSHTunnelWithJumpsWithOptions is like NewSSHTunnelWithJumps but applies opts
+// to the resulting tunnel, e.g. to install a verifying HostKeyCallback on every hop
+// in the chain in place of the default insecure "accept any key" behavior.
+func NewSSHTunnelWithJumpsWithOptions(jumps []string, auth ssh.AuthMethod, destination string, localport string, opts Options) *SSHTunnel {
+	sshTunnel := NewSSHTunnelWithJumps(jumps, auth, destination, localport)
+	opts.apply(sshTunnel)
+	return sshTunnel
+}
+
+// KnownHostsCallback returns an ssh.HostKeyCallback that verifies a presented host
+// key against the known_hosts file at path, in the OpenSSH format read by
+// golang.org/x/crypto/ssh/knownhosts. It rejects any host not already present in
+// the file.
+func KnownHostsCallback(path string) (ssh.HostKeyCallback, error) {
+	return knownhosts.New(path)
+}
+
+// KnownHostsCallbackTOFU is like KnownHostsCallback, but on first connect to a host
+// not yet present in the known_hosts file, it appends the presented key instead of
+// rejecting it (trust-on-first-use). A host whose key has changed since it was
+// first recorded is still rejected.
+func KnownHostsCallbackTOFU(path string) (ssh.HostKeyCallback, error) {
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		err := callback(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) != 0 {
+			// Either a non-knownhosts error, or the host is known under a
+			// different key: don't silently trust it.
+			return err
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+		if _, err = f.WriteString(line + "\n"); err != nil {
+			return err
+		}
+
+		// Reload so the key just recorded is checked on later connects.
+		reloaded, err := knownhosts.New(path)
+		if err != nil {
+			return err
+		}
+		callback = reloaded
+		return nil
+	}, nil
+}
@@ -0,0 +1,187 @@
+package sshtunnel
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// LocalForwardSpec describes one local->remote mapping multiplexed over a
+// Session's shared SSH connection: Listen is bound locally, and each connection
+// accepted there is forwarded, via the SSH server, to Forward.
+type LocalForwardSpec struct {
+	Listen  *Address
+	Forward *Address
+}
+
+// RemoteForwardSpec describes one remote->local mapping multiplexed over a
+// Session's shared SSH connection: Listen is bound on the SSH server, and each
+// connection it accepts on our behalf is forwarded to Forward on this machine.
+type RemoteForwardSpec struct {
+	Listen  *Address
+	Forward *Address
+}
+
+// Session holds a single persistent *ssh.Client and serves any number of
+// LocalForwardSpec/RemoteForwardSpec mappings over it, each on its own goroutine
+// and listener but sharing one SSH connection and handshake. This is the
+// multi-forward counterpart to SSHTunnel, which serves a single mapping over its
+// own cached SSH connection; Session shares one connection across many mappings the
+// way a real SSH client does when given several "-L"/"-R" flags for the same server.
+type Session struct {
+	Log logger
+
+	client *ssh.Client
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func (session *Session) logf(fmt string, args ...interface{}) {
+	if session.Log != nil {
+		session.Log.Printf(fmt, args...)
+	}
+}
+
+// NewSession dials server once and returns a Session ready to serve forwards over
+// that connection via AddLocalForward and AddRemoteForward.
+func NewSession(server string, config *ssh.ClientConfig) (*Session, error) {
+	addr := NewAddress(server)
+	if addr.Port == 0 {
+		addr.Port = 22
+	}
+
+	client, err := ssh.Dial("tcp", addr.String(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{client: client}, nil
+}
+
+func (session *Session) trackListener(listener net.Listener) {
+	session.mu.Lock()
+	session.listeners = append(session.listeners, listener)
+	session.mu.Unlock()
+}
+
+// pipe relays traffic between a and b in both directions and waits for both
+// io.Copy calls to finish before closing them, so Close can drain in-flight
+// forwards before tearing down the shared SSH connection. a is assumed to already
+// be counted in session.wg by the caller, from before the dial that produced b.
+func (session *Session) pipe(a, b net.Conn) {
+	var directions sync.WaitGroup
+	directions.Add(2)
+	copyConn := func(writer, reader net.Conn) {
+		defer directions.Done()
+		_, err := io.Copy(writer, reader)
+		if err != nil {
+			session.logf("io.Copy error: %s", err)
+		}
+	}
+	go copyConn(a, b)
+	go copyConn(b, a)
+
+	go func() {
+		directions.Wait()
+		a.Close()
+		b.Close()
+		session.wg.Done()
+	}()
+}
+
+// AddLocalForward binds spec.Listen locally and, for every connection it accepts,
+// dials spec.Forward over the session's shared SSH connection and relays traffic
+// between the two. It returns once the listener is bound; serving happens on
+// background goroutines for the lifetime of the Session.
+func (session *Session) AddLocalForward(spec LocalForwardSpec) error {
+	listener, err := net.Listen("tcp", spec.Listen.String())
+	if err != nil {
+		return err
+	}
+	spec.Listen.Port = listener.Addr().(*net.TCPAddr).Port
+	session.trackListener(listener)
+
+	go func() {
+		for {
+			localConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			session.logf("accepted connection for %s", spec.Listen.String())
+			session.wg.Add(1)
+
+			go func() {
+				remoteConn, err := session.client.Dial("tcp", spec.Forward.String())
+				if err != nil {
+					session.logf("remote dial error: %s", err)
+					localConn.Close()
+					session.wg.Done()
+					return
+				}
+				session.logf("connected to %s\n", spec.Forward.String())
+				session.pipe(localConn, remoteConn)
+			}()
+		}
+	}()
+
+	return nil
+}
+
+// AddRemoteForward asks the SSH server to listen on spec.Listen and, for every
+// connection it accepts on our behalf, dials spec.Forward locally and relays
+// traffic between the two. It returns once the listener is bound; serving happens
+// on background goroutines for the lifetime of the Session.
+func (session *Session) AddRemoteForward(spec RemoteForwardSpec) error {
+	listener, err := session.client.Listen("tcp", spec.Listen.String())
+	if err != nil {
+		return err
+	}
+	session.trackListener(listener)
+
+	go func() {
+		for {
+			remoteConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			session.logf("accepted remote connection for %s", spec.Listen.String())
+			session.wg.Add(1)
+
+			go func() {
+				localConn, err := net.Dial("tcp", spec.Forward.String())
+				if err != nil {
+					session.logf("local dial error: %s", err)
+					remoteConn.Close()
+					session.wg.Done()
+					return
+				}
+				session.logf("connected to %s\n", spec.Forward.String())
+				session.pipe(remoteConn, localConn)
+			}()
+		}
+	}()
+
+	return nil
+}
+
+// Close stops every forward added to the session, waits for in-flight connections
+// to finish relaying, and closes the shared SSH connection. It is idempotent.
+func (session *Session) Close() {
+	session.closeOnce.Do(func() {
+		session.mu.Lock()
+		listeners := session.listeners
+		session.mu.Unlock()
+
+		for _, listener := range listeners {
+			listener.Close()
+		}
+
+		session.wg.Wait()
+		session.client.Close()
+	})
+}
@@ -1,11 +1,15 @@
 package sshtunnel
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -42,16 +46,76 @@ func (endpoint *Address) String() string {
 	return fmt.Sprintf("%s:%d", endpoint.Host, endpoint.Port)
 }
 
+// TunnelDirection selects which end of the SSH connection does the listening.
+type TunnelDirection int
+
+const (
+	// LocalForward listens locally and forwards accepted connections to Remote via
+	// the SSH server, i.e. the "ssh -L" use case. This is the default.
+	LocalForward TunnelDirection = iota
+	// RemoteForward listens on the SSH server (Remote) and forwards accepted
+	// connections to Local, i.e. the "ssh -R" use case.
+	RemoteForward
+)
+
 type SSHTunnel struct {
-	Local    *Address
-	Server   *Address
-	Remote   *Address
-	Config   *ssh.ClientConfig
-	Log      logger
-	Conns    []net.Conn
-	SvrConns []*ssh.Client
-	isOpen   bool
-	close    chan interface{}
+	Direction TunnelDirection
+	Local     *Address
+	// Server is the first (and usually only) SSH hop. Deprecated: use Servers
+	// instead. Server is still honored as a fallback for Servers[0] when Servers is
+	// empty, so a tunnel built by hand as &SSHTunnel{Server: s, Config: c, ...}
+	// (the only way to build one before Servers/Configs existed) keeps working.
+	Server *Address
+	// Servers is the chain of SSH hops to dial through, in order, before reaching
+	// Remote, mirroring OpenSSH's ProxyJump. A single-hop tunnel has len(Servers) == 1.
+	Servers []*Address
+	Remote  *Address
+	// Config is the ClientConfig for the first hop. Deprecated: use Configs instead.
+	// Config is still honored as a fallback for Configs[0] when Configs is empty;
+	// see Server.
+	Config *ssh.ClientConfig
+	// Configs holds one ClientConfig per entry in Servers.
+	Configs []*ssh.ClientConfig
+	Log     logger
+
+	// CloseConnectionOnDialFailure, when true, closes an accepted local connection
+	// immediately if the subsequent SSH or remote dial fails, instead of leaving it
+	// open with nothing to relay its traffic.
+	CloseConnectionOnDialFailure bool
+
+	// DrainTimeout bounds how long Close waits for in-flight connections to finish
+	// relaying after the listener has stopped accepting new ones, before forcibly
+	// closing whatever is left. Zero means wait indefinitely.
+	DrainTimeout time.Duration
+
+	// KeepAliveInterval, if positive, sends an SSH keepalive request on this
+	// interval for as long as the server connection is cached, to keep NAT mappings
+	// and idle firewalls from dropping the connection. Zero disables keepalives.
+	KeepAliveInterval time.Duration
+	// KeepAliveMaxFailures is how many consecutive keepalive failures are tolerated
+	// before the cached server connection is dropped and a fresh dial is attempted
+	// on the next incoming connection. Defaults to 3 if zero.
+	KeepAliveMaxFailures int
+	// ReconnectBackoff computes the delay before the next reconnect attempt, given
+	// how many consecutive dial attempts have failed so far (starting at 0). If
+	// nil, an exponential backoff starting at one second and capping at 30 seconds
+	// is used.
+	ReconnectBackoff func(attempt int) time.Duration
+
+	mu        sync.Mutex
+	conns     map[net.Conn]struct{}
+	svrConns  map[*ssh.Client]struct{}
+	wg        sync.WaitGroup
+	listener  net.Listener
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	stopped   int32 // atomic; set by Close so reconnect loops stop retrying
+
+	serverConnMu sync.Mutex
+	serverConn   *ssh.Client
+	dialAttempts int
+	nextRetry    time.Time
+	lastDialErr  error
 }
 
 func (tunnel *SSHTunnel) logf(fmt string, args ...interface{}) {
@@ -60,97 +124,499 @@ func (tunnel *SSHTunnel) logf(fmt string, args ...interface{}) {
 	}
 }
 
-func newConnectionWaiter(listener net.Listener, c chan net.Conn) {
-	conn, err := listener.Accept()
+func (tunnel *SSHTunnel) trackConn(conn net.Conn) {
+	tunnel.mu.Lock()
+	if tunnel.conns == nil {
+		tunnel.conns = make(map[net.Conn]struct{})
+	}
+	tunnel.conns[conn] = struct{}{}
+	tunnel.mu.Unlock()
+}
+
+func (tunnel *SSHTunnel) untrackConn(conn net.Conn) {
+	tunnel.mu.Lock()
+	delete(tunnel.conns, conn)
+	tunnel.mu.Unlock()
+}
+
+func (tunnel *SSHTunnel) isStopped() bool {
+	return atomic.LoadInt32(&tunnel.stopped) != 0
+}
+
+func (tunnel *SSHTunnel) trackSvrConn(client *ssh.Client) {
+	tunnel.mu.Lock()
+	if tunnel.svrConns == nil {
+		tunnel.svrConns = make(map[*ssh.Client]struct{})
+	}
+	tunnel.svrConns[client] = struct{}{}
+	tunnel.mu.Unlock()
+}
+
+// pipe relays traffic between a and b in both directions and waits for both
+// io.Copy calls to finish before closing them. a is assumed to already be tracked
+// in tunnel.conns and counted in tunnel.wg by the caller, from before the dial that
+// produced b; pipe tracks b itself and untracks both on completion.
+func (tunnel *SSHTunnel) pipe(a, b net.Conn) {
+	tunnel.trackConn(b)
+
+	var directions sync.WaitGroup
+	directions.Add(2)
+	copyConn := func(writer, reader net.Conn) {
+		defer directions.Done()
+		_, err := io.Copy(writer, reader)
+		if err != nil {
+			tunnel.logf("io.Copy error: %s", err)
+		}
+	}
+	go copyConn(a, b)
+	go copyConn(b, a)
+
+	go func() {
+		directions.Wait()
+		a.Close()
+		b.Close()
+		tunnel.untrackConn(a)
+		tunnel.untrackConn(b)
+		tunnel.wg.Done()
+	}()
+}
+
+// hopServers returns the server chain and matching configs to dial, falling back
+// to the single-hop Server/Config fields when Servers/Configs haven't been
+// populated. This keeps a tunnel built by hand as &SSHTunnel{Server: s, Config: c,
+// ...} - the way every tunnel was built before Servers/Configs existed - working
+// exactly as it did before.
+func (tunnel *SSHTunnel) hopServers() ([]*Address, []*ssh.ClientConfig) {
+	servers := tunnel.Servers
+	configs := tunnel.Configs
+	if len(servers) == 0 && tunnel.Server != nil {
+		servers = []*Address{tunnel.Server}
+	}
+	if len(configs) == 0 && tunnel.Config != nil {
+		configs = []*ssh.ClientConfig{tunnel.Config}
+	}
+	return servers, configs
+}
+
+// dialServers dials the tunnel's server chain in order, hopping from each SSH
+// client to the next over an SSH-tunnelled connection, and returns the *ssh.Client
+// for the last hop, from which tunnel.Remote (or, for RemoteForward, the Listen
+// call) is reached. Every intermediate client is tracked for teardown in Close.
+func (tunnel *SSHTunnel) dialServers() (*ssh.Client, error) {
+	servers, configs := tunnel.hopServers()
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("sshtunnel: no server configured (set Servers or Server)")
+	}
+
+	client, err := ssh.Dial("tcp", servers[0].String(), configs[0])
 	if err != nil {
+		return nil, fmt.Errorf("server dial error: %s", err)
+	}
+	tunnel.logf("connected to %s (1 of %d)\n", servers[0].String(), len(servers))
+	tunnel.trackSvrConn(client)
+
+	for i := 1; i < len(servers); i++ {
+		next := servers[i]
+		conn, err := client.Dial("tcp", next.String())
+		if err != nil {
+			return nil, fmt.Errorf("jump dial error: %s", err)
+		}
+		ncc, chans, reqs, err := ssh.NewClientConn(conn, next.String(), configs[i])
+		if err != nil {
+			return nil, fmt.Errorf("jump handshake error: %s", err)
+		}
+		client = ssh.NewClient(ncc, chans, reqs)
+		tunnel.logf("connected to %s (%d of %d)\n", next.String(), i+1, len(servers))
+		tunnel.trackSvrConn(client)
+	}
+
+	return client, nil
+}
+
+func defaultReconnectBackoff(attempt int) time.Duration {
+	delay := time.Second
+	for i := 0; i < attempt && delay < 30*time.Second; i++ {
+		delay *= 2
+	}
+	if delay > 30*time.Second {
+		delay = 30 * time.Second
+	}
+	return delay
+}
+
+// getServerConn returns the cached server connection (the last hop of
+// tunnel.Servers), dialing or redialing it as needed. Incoming connections share
+// this single *ssh.Client rather than each triggering their own handshake, and the
+// connection is kept alive and transparently reconnected (with backoff) if
+// KeepAliveInterval detects it has died.
+func (tunnel *SSHTunnel) getServerConn() (*ssh.Client, error) {
+	tunnel.serverConnMu.Lock()
+	defer tunnel.serverConnMu.Unlock()
+
+	if tunnel.serverConn != nil {
+		return tunnel.serverConn, nil
+	}
+
+	if time.Now().Before(tunnel.nextRetry) {
+		return nil, tunnel.lastDialErr
+	}
+
+	client, err := tunnel.dialServers()
+	if err != nil {
+		backoff := tunnel.ReconnectBackoff
+		if backoff == nil {
+			backoff = defaultReconnectBackoff
+		}
+		tunnel.nextRetry = time.Now().Add(backoff(tunnel.dialAttempts))
+		tunnel.dialAttempts++
+		tunnel.lastDialErr = err
+		return nil, err
+	}
+
+	tunnel.dialAttempts = 0
+	tunnel.lastDialErr = nil
+	tunnel.serverConn = client
+	go tunnel.monitorServerConn(client)
+	return client, nil
+}
+
+// dropServerConn closes client and, if it is still the cached server connection,
+// clears the cache so the next getServerConn call (or remote-listener reconnect)
+// dials a fresh one. client may already have been replaced by a newer dial, in
+// which case only the close happens. It is shared by monitorServerConn's
+// keepalive-failure path and serveContext's RemoteForward reconnect loop.
+func (tunnel *SSHTunnel) dropServerConn(client *ssh.Client) {
+	tunnel.serverConnMu.Lock()
+	if tunnel.serverConn == client {
+		tunnel.serverConn = nil
+	}
+	tunnel.serverConnMu.Unlock()
+	client.Close()
+}
+
+// monitorServerConn sends periodic SSH keepalive requests over client and drops it
+// as the cached server connection once KeepAliveMaxFailures consecutive requests
+// fail, so the next call to getServerConn dials a fresh connection.
+func (tunnel *SSHTunnel) monitorServerConn(client *ssh.Client) {
+	if tunnel.KeepAliveInterval <= 0 {
 		return
 	}
-	c <- conn
+	maxFailures := tunnel.KeepAliveMaxFailures
+	if maxFailures <= 0 {
+		maxFailures = 3
+	}
+
+	ticker := time.NewTicker(tunnel.KeepAliveInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	for range ticker.C {
+		tunnel.serverConnMu.Lock()
+		isCurrent := tunnel.serverConn == client
+		tunnel.serverConnMu.Unlock()
+		if !isCurrent {
+			return
+		}
+
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		if err != nil {
+			failures++
+			tunnel.logf("keepalive failed (%d/%d): %s", failures, maxFailures, err)
+			if failures < maxFailures {
+				continue
+			}
+			tunnel.logf("keepalive max failures reached, dropping server connection")
+			tunnel.dropServerConn(client)
+			return
+		}
+		failures = 0
+	}
 }
 
-func (tunnel *SSHTunnel) Start() error {
+// Listen binds the tunnel's listening side without serving any connections yet:
+// for LocalForward it binds tunnel.Local directly; for RemoteForward it dials the SSH
+// server and asks it to listen on tunnel.Remote. Splitting this out of Start lets a
+// caller bind synchronously (so e.g. tunnel.Local.Port is populated before any
+// goroutine starts serving), handle the bind error inline, and pass the listener
+// through to Serve, optionally substituting one of its own.
+func (tunnel *SSHTunnel) Listen() (net.Listener, error) {
+	if tunnel.Direction == RemoteForward {
+		return tunnel.listenRemote()
+	}
+	return tunnel.listenLocal()
+}
+
+func (tunnel *SSHTunnel) listenLocal() (net.Listener, error) {
 	listener, err := net.Listen("tcp", tunnel.Local.String())
 	if err != nil {
-		return err
+		return nil, err
 	}
-	tunnel.isOpen = true
 	tunnel.Local.Port = listener.Addr().(*net.TCPAddr).Port
+	return listener, nil
+}
+
+func (tunnel *SSHTunnel) listenRemote() (net.Listener, error) {
+	serverConn, err := tunnel.getServerConn()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := serverConn.Listen("tcp", tunnel.Remote.String())
+	if err != nil {
+		return nil, err
+	}
+	return listener, nil
+}
 
+// reconnectListenRemote retries dialing the server chain and re-listening on
+// tunnel.Remote, backing off between attempts the same way getServerConn does,
+// until it succeeds or ctx is done or the tunnel is closed. It's used by
+// serveContext to recover a RemoteForward tunnel whose listener died because the
+// cached server connection it came from was dropped out from under it.
+func (tunnel *SSHTunnel) reconnectListenRemote(ctx context.Context) (net.Listener, error) {
+	attempt := 0
 	for {
-		if !tunnel.isOpen {
-			break
+		listener, err := tunnel.listenRemote()
+		if err == nil {
+			return listener, nil
 		}
+		if tunnel.isStopped() || ctx.Err() != nil {
+			return nil, err
+		}
+		tunnel.logf("remote listen error, retrying: %s", err)
 
-		c := make(chan net.Conn)
-		go newConnectionWaiter(listener, c)
-		tunnel.logf("listening for new connections...")
-
+		backoff := tunnel.ReconnectBackoff
+		if backoff == nil {
+			backoff = defaultReconnectBackoff
+		}
+		delay := backoff(attempt)
+		attempt++
 		select {
-		case <-tunnel.close:
-			tunnel.logf("close signal received, closing...")
-			tunnel.isOpen = false
-		case conn := <-c:
-			tunnel.Conns = append(tunnel.Conns, conn)
-			tunnel.logf("accepted connection")
-			go tunnel.forward(conn)
-		}
-	}
-	var total int
-	total = len(tunnel.Conns)
-	for i, conn := range tunnel.Conns {
-		tunnel.logf("closing the netConn (%d of %d)", i+1, total)
-		err := conn.Close()
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Serve accepts connections from listener until Close is called, forwarding each one according to tunnel.Direction. The listener is typically
+// obtained from Listen, but callers may pass in their own (e.g. for tests, or a Unix
+// socket listener).
+func (tunnel *SSHTunnel) Serve(listener net.Listener) error {
+	return tunnel.serveContext(context.Background(), listener)
+}
+
+// StartContext is like Start, but stops as soon as ctx is done in addition to
+// responding to Close.
+func (tunnel *SSHTunnel) StartContext(ctx context.Context) error {
+	listener, err := tunnel.Listen()
+	if err != nil {
+		return err
+	}
+	return tunnel.serveContext(ctx, listener)
+}
+
+// acceptLoop accepts connections from listener, tracking and forwarding each one,
+// until Accept fails - either because Close or ctx stopped the tunnel, or, for
+// RemoteForward, because the underlying SSH connection the listener came from died.
+func (tunnel *SSHTunnel) acceptLoop(listener net.Listener, forward func(net.Conn)) {
+	for {
+		conn, err := listener.Accept()
 		if err != nil {
-			tunnel.logf(err.Error())
+			return
 		}
+		tunnel.logf("accepted connection")
+		tunnel.trackConn(conn)
+		tunnel.wg.Add(1)
+		go forward(conn)
 	}
-	total = len(tunnel.SvrConns)
-	for i, conn := range tunnel.SvrConns {
-		tunnel.logf("closing the serverConn (%d of %d)", i+1, total)
-		err := conn.Close()
+}
+
+func (tunnel *SSHTunnel) serveContext(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tunnel.mu.Lock()
+	tunnel.listener = listener
+	tunnel.cancel = cancel
+	tunnel.mu.Unlock()
+
+	// Close may have run concurrently before tunnel.listener/tunnel.cancel above were
+	// ever set, in which case it found both nil and had nothing to close or cancel.
+	// Since closeOnce means it will never run again, catch up on that now or Accept
+	// below blocks forever with no way left to unblock it.
+	if tunnel.isStopped() {
+		cancel()
+		listener.Close()
+	}
+
+	go func() {
+		<-ctx.Done()
+		tunnel.mu.Lock()
+		current := tunnel.listener
+		tunnel.mu.Unlock()
+		if current != nil {
+			current.Close()
+		}
+	}()
+
+	forward := tunnel.forward
+	if tunnel.Direction == RemoteForward {
+		forward = tunnel.forwardRemote
+	}
+
+	for {
+		tunnel.logf("listening for new connections...")
+		tunnel.acceptLoop(listener, forward)
+
+		if tunnel.Direction != RemoteForward || tunnel.isStopped() || ctx.Err() != nil {
+			break
+		}
+
+		// The server connection this listener came from died out from under it
+		// (e.g. a keepalive failure, or the network dropping); drop it and keep
+		// retrying a fresh dial and re-listen so the tunnel recovers on its own.
+		tunnel.logf("remote listener lost, reconnecting...")
+		tunnel.serverConnMu.Lock()
+		client := tunnel.serverConn
+		tunnel.serverConnMu.Unlock()
+		if client != nil {
+			tunnel.dropServerConn(client)
+		}
+
+		var err error
+		listener, err = tunnel.reconnectListenRemote(ctx)
 		if err != nil {
-			tunnel.logf(err.Error())
+			break
 		}
+		tunnel.mu.Lock()
+		tunnel.listener = listener
+		tunnel.mu.Unlock()
 	}
-	err = listener.Close()
+
+	tunnel.logf("listener closed")
+	return nil
+}
+
+// Start binds and serves the tunnel, blocking until Close is called. It is
+// equivalent to calling Listen followed by Serve, and is kept for callers that don't
+// need the finer-grained control the split offers.
+func (tunnel *SSHTunnel) Start() error {
+	listener, err := tunnel.Listen()
 	if err != nil {
 		return err
 	}
-	tunnel.logf("tunnel closed")
-	return nil
+	return tunnel.Serve(listener)
 }
 
+// forward handles a connection accepted on tunnel.Local (the LocalForward
+// direction) by dialing tunnel.Remote through the SSH server and relaying traffic
+// between the two. localConn is assumed to already be tracked in tunnel.conns and
+// counted in tunnel.wg by the caller (the Accept loop), from before this dial could
+// block, so Close's drain wait sees it for the connection's entire lifetime.
 func (tunnel *SSHTunnel) forward(localConn net.Conn) {
-	serverConn, err := ssh.Dial("tcp", tunnel.Server.String(), tunnel.Config)
+	serverConn, err := tunnel.getServerConn()
 	if err != nil {
-		tunnel.logf("server dial error: %s", err)
+		tunnel.logf(err.Error())
+		if tunnel.CloseConnectionOnDialFailure {
+			localConn.Close()
+		}
+		tunnel.untrackConn(localConn)
+		tunnel.wg.Done()
 		return
 	}
-	tunnel.logf("connected to %s (1 of 2)\n", tunnel.Server.String())
-	tunnel.SvrConns = append(tunnel.SvrConns, serverConn)
-	
+
 	remoteConn, err := serverConn.Dial("tcp", tunnel.Remote.String())
 	if err != nil {
 		tunnel.logf("remote dial error: %s", err)
+		if tunnel.CloseConnectionOnDialFailure {
+			localConn.Close()
+		}
+		tunnel.untrackConn(localConn)
+		tunnel.wg.Done()
 		return
 	}
-	tunnel.Conns = append(tunnel.Conns, remoteConn)
-	tunnel.logf("connected to %s (2 of 2)\n", tunnel.Remote.String())
-	copyConn := func(writer, reader net.Conn) {
-		_, err := io.Copy(writer, reader)
-		if err != nil {
-			tunnel.logf("io.Copy error: %s", err)
+	tunnel.logf("connected to %s\n", tunnel.Remote.String())
+	tunnel.pipe(localConn, remoteConn)
+}
+
+// forwardRemote handles a connection accepted by the SSH server on tunnel.Remote
+// (the RemoteForward direction) by dialing tunnel.Local and relaying traffic
+// between the two. remoteConn is assumed to already be tracked in tunnel.conns and
+// counted in tunnel.wg by the caller, for the same reason as in forward.
+func (tunnel *SSHTunnel) forwardRemote(remoteConn net.Conn) {
+	localConn, err := net.Dial("tcp", tunnel.Local.String())
+	if err != nil {
+		tunnel.logf("local dial error: %s", err)
+		if tunnel.CloseConnectionOnDialFailure {
+			remoteConn.Close()
 		}
+		tunnel.untrackConn(remoteConn)
+		tunnel.wg.Done()
+		return
 	}
-	go copyConn(localConn, remoteConn)
-	go copyConn(remoteConn, localConn)
-
-	return
+	tunnel.logf("connected to %s\n", tunnel.Local.String())
+	tunnel.pipe(remoteConn, localConn)
 }
 
+// Close stops the tunnel: it closes the listener to unblock Accept (and cancels the
+// context passed to StartContext, if any), waits up to DrainTimeout for in-flight
+// connections to finish relaying, then forcibly closes anything still open. It is
+// idempotent and safe to call from any goroutine, including while Serve is running.
 func (tunnel *SSHTunnel) Close() {
-	tunnel.close <- struct{}{}
-	return
+	tunnel.closeOnce.Do(func() {
+		atomic.StoreInt32(&tunnel.stopped, 1)
+
+		tunnel.mu.Lock()
+		listener := tunnel.listener
+		cancel := tunnel.cancel
+		tunnel.mu.Unlock()
+
+		if cancel != nil {
+			cancel()
+		}
+		if listener != nil {
+			listener.Close()
+		}
+
+		drained := make(chan struct{})
+		go func() {
+			tunnel.wg.Wait()
+			close(drained)
+		}()
+
+		if tunnel.DrainTimeout > 0 {
+			select {
+			case <-drained:
+			case <-time.After(tunnel.DrainTimeout):
+				tunnel.logf("drain timeout exceeded, closing remaining connections")
+			}
+		} else {
+			<-drained
+		}
+
+		tunnel.mu.Lock()
+		for conn := range tunnel.conns {
+			conn.Close()
+		}
+		for client := range tunnel.svrConns {
+			client.Close()
+		}
+		tunnel.mu.Unlock()
+
+		tunnel.logf("tunnel closed")
+	})
+}
+
+func newHopConfig(server *Address) *ssh.ClientConfig {
+	return &ssh.ClientConfig{
+		User: server.User,
+		Auth: []ssh.AuthMethod{},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			// Always accept key.
+			return nil
+		},
+	}
 }
 
 // NewSSHTunnel creates a new single-use tunnel. Supplying "0" for localport will use a random port.
@@ -162,19 +628,75 @@ func NewSSHTunnel(tunnel string, auth ssh.AuthMethod, destination string, localp
 		server.Port = 22
 	}
 
+	config := newHopConfig(server)
+	config.Auth = []ssh.AuthMethod{auth}
+
 	sshTunnel := &SSHTunnel{
-		Config: &ssh.ClientConfig{
-			User: server.User,
-			Auth: []ssh.AuthMethod{auth},
-			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-				// Always accept key.
-				return nil
-			},
-		},
-		Local:  localEndpoint,
-		Server: server,
-		Remote: NewAddress(destination),
-		close:  make(chan interface{}),
+		Config:  config,
+		Configs: []*ssh.ClientConfig{config},
+		Local:   localEndpoint,
+		Server:  server,
+		Servers: []*Address{server},
+		Remote:  NewAddress(destination),
+	}
+
+	return sshTunnel
+}
+
+// NewReverseSSHTunnel creates a new single-use tunnel in the RemoteForward direction:
+// the SSH server at tunnel listens on remote and forwards accepted connections back to
+// localDestination on this machine, i.e. the "ssh -R" use case.
+func NewReverseSSHTunnel(tunnel string, auth ssh.AuthMethod, remote string, localDestination string) *SSHTunnel {
+	server := NewAddress(tunnel)
+	if server.Port == 0 {
+		server.Port = 22
+	}
+
+	config := newHopConfig(server)
+	config.Auth = []ssh.AuthMethod{auth}
+
+	sshTunnel := &SSHTunnel{
+		Config:    config,
+		Configs:   []*ssh.ClientConfig{config},
+		Direction: RemoteForward,
+		Local:     NewAddress(localDestination),
+		Server:    server,
+		Servers:   []*Address{server},
+		Remote:    NewAddress(remote),
+	}
+
+	return sshTunnel
+}
+
+// NewSSHTunnelWithJumps creates a new single-use tunnel that hops through the given
+// chain of SSH bastions, in order, before reaching destination, mirroring OpenSSH's
+// ProxyJump. Each entry in jumps is a "user@host:port" address; auth is used to
+// authenticate to every hop in the chain. Supplying "0" for localport will use a
+// random port.
+func NewSSHTunnelWithJumps(jumps []string, auth ssh.AuthMethod, destination string, localport string) *SSHTunnel {
+	localEndpoint := NewAddress("localhost:" + localport)
+
+	servers := make([]*Address, len(jumps))
+	configs := make([]*ssh.ClientConfig, len(jumps))
+	for i, jump := range jumps {
+		server := NewAddress(jump)
+		if server.Port == 0 {
+			server.Port = 22
+		}
+		config := newHopConfig(server)
+		config.Auth = []ssh.AuthMethod{auth}
+
+		servers[i] = server
+		configs[i] = config
+	}
+
+	sshTunnel := &SSHTunnel{
+		Config:  configs[0],
+		Configs: configs,
+		Local:   localEndpoint,
+		Server:  servers[0],
+		Servers: servers,
+		Remote:  NewAddress(destination),
 	}
 
 	return sshTunnel
@@ -0,0 +1,118 @@
+package sshtunnel
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestNewAddress(t *testing.T) {
+	addr := NewAddress("user@example.com:2222")
+	if addr.User != "user" {
+		t.Errorf("User = %q, want %q", addr.User, "user")
+	}
+	if addr.Host != "example.com" {
+		t.Errorf("Host = %q, want %q", addr.Host, "example.com")
+	}
+	if addr.Port != 2222 {
+		t.Errorf("Port = %d, want %d", addr.Port, 2222)
+	}
+}
+
+func TestListenBindsLocalPort(t *testing.T) {
+	tunnel := &SSHTunnel{
+		Local: NewAddress("localhost:0"),
+	}
+
+	listener, err := tunnel.Listen()
+	if err != nil {
+		t.Fatalf("Listen() error: %s", err)
+	}
+	defer listener.Close()
+
+	if tunnel.Local.Port == 0 {
+		t.Error("Listen() did not populate tunnel.Local.Port")
+	}
+	if listener.Addr().(*net.TCPAddr).Port != tunnel.Local.Port {
+		t.Errorf("listener port = %d, tunnel.Local.Port = %d", listener.Addr().(*net.TCPAddr).Port, tunnel.Local.Port)
+	}
+}
+
+func TestCloseConnectionOnDialFailureClosesLocalConn(t *testing.T) {
+	tunnel := &SSHTunnel{
+		// Nothing is listening on this port, so the server dial in getServerConn
+		// fails immediately without needing a real SSH server.
+		Servers:                      []*Address{NewAddress("127.0.0.1:1")},
+		Configs:                      []*ssh.ClientConfig{{}},
+		Remote:                       NewAddress("127.0.0.1:1"),
+		CloseConnectionOnDialFailure: true,
+	}
+
+	server, client := net.Pipe()
+	defer server.Close()
+
+	tunnel.trackConn(client)
+	tunnel.wg.Add(1)
+	tunnel.forward(client)
+
+	// forward should have closed client and released the connection it was handed,
+	// both as a direct result of CloseConnectionOnDialFailure and so Close's drain
+	// wait isn't left hanging on this connection forever.
+	if _, err := client.Write([]byte("x")); err == nil {
+		t.Error("forward() did not close localConn on dial failure")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tunnel.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("forward() left tunnel.wg uncounted after a dial failure")
+	}
+}
+
+func TestDefaultReconnectBackoff(t *testing.T) {
+	if got := defaultReconnectBackoff(0); got != time.Second {
+		t.Errorf("defaultReconnectBackoff(0) = %s, want %s", got, time.Second)
+	}
+	if got := defaultReconnectBackoff(1); got != 2*time.Second {
+		t.Errorf("defaultReconnectBackoff(1) = %s, want %s", got, 2*time.Second)
+	}
+	if got := defaultReconnectBackoff(10); got != 30*time.Second {
+		t.Errorf("defaultReconnectBackoff(10) = %s, want cap of %s", got, 30*time.Second)
+	}
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	tunnel := &SSHTunnel{
+		Local: NewAddress("localhost:0"),
+	}
+
+	listener, err := tunnel.Listen()
+	if err != nil {
+		t.Fatalf("Listen() error: %s", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- tunnel.Serve(listener) }()
+
+	// Close is called right after Serve starts, racing serveContext's registration
+	// of tunnel.listener/tunnel.cancel - exactly the window serveContext must catch
+	// up on by checking isStopped itself rather than relying on Close alone.
+	tunnel.Close()
+	tunnel.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() error: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Serve() did not return after Close()")
+	}
+}